@@ -0,0 +1,360 @@
+package json
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+)
+
+// RawMessage is a raw encoded JSON value, in the style of
+// encoding/json.RawMessage. A struct field of this type is never decoded
+// further than capturing its byte span, and is written back out verbatim on
+// Serialize, avoiding a decode/encode roundtrip for payloads the caller
+// wants to pass through untouched.
+type RawMessage []byte
+
+var rawMessageType = reflect.TypeOf(RawMessage{})
+
+// Any is a lazily-parsed JSON value: parsing into an Any only records the
+// byte span it occupies, and the actual type conversion is deferred until
+// one of the getters below is called. This is a significant win when a
+// caller only needs a field or two out of a large document, since the
+// expensive path (parseInt/parseFloat and string unescaping) is
+// never run for the parts that are never asked for.
+type Any interface {
+	// Get walks into an object field (string key) or array element (int
+	// index) for each key in turn, returning an Any for whatever it finds
+	// (or an invalid Any if the path doesn't exist).
+	Get(keys ...interface{}) Any
+	ToString() string
+	ToInt() int
+	ToFloat() float64
+	ToBool() bool
+	Keys() []string
+	Size() int
+	MustArray() []Any
+	MustMap() map[string]Any
+}
+
+var anyType = reflect.TypeOf((*Any)(nil)).Elem()
+
+// rawBacked is implemented by Any values, letting serializeValue write them
+// back out byte-for-byte instead of re-encoding their decoded contents.
+type rawBacked interface {
+	rawBytes() []byte
+}
+
+type anyKind int
+
+const (
+	anyInvalid anyKind = iota
+	anyObject
+	anyArray
+	anyString
+	anyNumber
+	anyBool
+	anyNull
+)
+
+type anyValue struct {
+	raw  []byte
+	kind anyKind
+}
+
+// ParseAny parses data into a lazily-evaluated Any, deferring the actual
+// decode until a getter is called.
+func ParseAny(data []byte) Any {
+	return newAny(data)
+}
+
+func newAny(raw []byte) *anyValue {
+	raw = bytes.TrimSpace(raw)
+	return &anyValue{raw: raw, kind: detectAnyKind(raw)}
+}
+
+func detectAnyKind(raw []byte) anyKind {
+	if len(raw) == 0 {
+		return anyInvalid
+	}
+	switch raw[0] {
+	case '{':
+		return anyObject
+	case '[':
+		return anyArray
+	case '"':
+		return anyString
+	case 't', 'f':
+		return anyBool
+	case 'n':
+		return anyNull
+	default:
+		return anyNumber
+	}
+}
+
+func (a *anyValue) rawBytes() []byte {
+	return a.raw
+}
+
+func (a *anyValue) ToString() string {
+	switch a.kind {
+	case anyString:
+		s, err := parseString(a.raw)
+		if err != nil {
+			return ""
+		}
+		return s
+	case anyNull:
+		return ""
+	default:
+		return string(a.raw)
+	}
+}
+
+func (a *anyValue) ToInt() int {
+	switch a.kind {
+	case anyNumber:
+		n, err := parseInt(a.raw)
+		if err != nil {
+			return 0
+		}
+		return int(n)
+	case anyString:
+		n, err := strconv.Atoi(a.ToString())
+		if err != nil {
+			return 0
+		}
+		return n
+	case anyBool:
+		if a.ToBool() {
+			return 1
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+func (a *anyValue) ToFloat() float64 {
+	switch a.kind {
+	case anyNumber:
+		f, err := parseFloat(a.raw)
+		if err != nil {
+			return 0
+		}
+		return f
+	case anyString:
+		f, err := strconv.ParseFloat(a.ToString(), 64)
+		if err != nil {
+			return 0
+		}
+		return f
+	default:
+		return 0
+	}
+}
+
+func (a *anyValue) ToBool() bool {
+	switch a.kind {
+	case anyBool:
+		return string(a.raw) == "true"
+	case anyNumber:
+		return a.ToFloat() != 0
+	case anyString:
+		return a.ToString() != ""
+	default:
+		return false
+	}
+}
+
+func (a *anyValue) Get(keys ...interface{}) Any {
+	if len(keys) == 0 {
+		return a
+	}
+
+	switch key := keys[0].(type) {
+	case string:
+		if a.kind != anyObject {
+			return newAny(nil)
+		}
+		span, ok := findObjectField(a.raw, key)
+		if !ok {
+			return newAny(nil)
+		}
+		return newAny(span).Get(keys[1:]...)
+	case int:
+		if a.kind != anyArray {
+			return newAny(nil)
+		}
+		span, ok := findArrayElement(a.raw, key)
+		if !ok {
+			return newAny(nil)
+		}
+		return newAny(span).Get(keys[1:]...)
+	default:
+		return newAny(nil)
+	}
+}
+
+func (a *anyValue) Keys() []string {
+	if a.kind != anyObject {
+		return nil
+	}
+
+	d := makeDeserializer(a.raw, nil)
+	d.consumeByte('{')
+	d.consumeWhitespace()
+
+	var keys []string
+	for {
+		if d.peekCurrent() == '}' || d.hasError() {
+			break
+		}
+		key := d.consumeKey()
+		if d.hasError() {
+			break
+		}
+		keys = append(keys, key)
+		d.skipValue()
+		if d.hasError() {
+			break
+		}
+		if d.peekCurrent() == ',' {
+			d.consumeByte(',')
+			d.consumeWhitespace()
+		}
+		d.consumeWhitespace()
+	}
+	return keys
+}
+
+func (a *anyValue) Size() int {
+	switch a.kind {
+	case anyObject:
+		return len(a.Keys())
+	case anyArray:
+		return len(a.MustArray())
+	default:
+		return 0
+	}
+}
+
+func (a *anyValue) MustArray() []Any {
+	if a.kind != anyArray {
+		return nil
+	}
+
+	d := makeDeserializer(a.raw, nil)
+	d.consumeByte('[')
+	d.consumeWhitespace()
+
+	var items []Any
+	for {
+		if d.peekCurrent() == ']' || d.hasError() {
+			break
+		}
+		span := d.skipValue()
+		if d.hasError() {
+			break
+		}
+		items = append(items, newAny(span))
+		if d.peekCurrent() == ',' {
+			d.consumeByte(',')
+		}
+		d.consumeWhitespace()
+	}
+	return items
+}
+
+func (a *anyValue) MustMap() map[string]Any {
+	if a.kind != anyObject {
+		return nil
+	}
+
+	d := makeDeserializer(a.raw, nil)
+	d.consumeByte('{')
+	d.consumeWhitespace()
+
+	m := map[string]Any{}
+	for {
+		if d.peekCurrent() == '}' || d.hasError() {
+			break
+		}
+		key := d.consumeKey()
+		if d.hasError() {
+			break
+		}
+		span := d.skipValue()
+		if d.hasError() {
+			break
+		}
+		m[key] = newAny(span)
+		if d.peekCurrent() == ',' {
+			d.consumeByte(',')
+			d.consumeWhitespace()
+		}
+		d.consumeWhitespace()
+	}
+	return m
+}
+
+// findObjectField scans raw (a `{...}` span) for key, returning the exact
+// byte span of its value without decoding any sibling field.
+func findObjectField(raw []byte, key string) ([]byte, bool) {
+	d := makeDeserializer(raw, nil)
+	d.consumeByte('{')
+	d.consumeWhitespace()
+
+	for {
+		if d.peekCurrent() == '}' || d.hasError() {
+			return nil, false
+		}
+		k := d.consumeKey()
+		if d.hasError() {
+			return nil, false
+		}
+		span := d.skipValue()
+		if d.hasError() {
+			return nil, false
+		}
+		if k == key {
+			return span, true
+		}
+		if d.peekCurrent() == ',' {
+			d.consumeByte(',')
+			d.consumeWhitespace()
+		}
+		d.consumeWhitespace()
+	}
+}
+
+// findArrayElement scans raw (a `[...]` span) for the element at index,
+// returning its exact byte span without decoding any sibling element.
+func findArrayElement(raw []byte, index int) ([]byte, bool) {
+	if index < 0 {
+		return nil, false
+	}
+
+	d := makeDeserializer(raw, nil)
+	d.consumeByte('[')
+	d.consumeWhitespace()
+
+	i := 0
+	for {
+		if d.peekCurrent() == ']' || d.hasError() {
+			return nil, false
+		}
+		span := d.skipValue()
+		if d.hasError() {
+			return nil, false
+		}
+		if i == index {
+			return span, true
+		}
+		i++
+		if d.peekCurrent() == ',' {
+			d.consumeByte(',')
+		}
+		d.consumeWhitespace()
+	}
+}
@@ -0,0 +1,98 @@
+package json
+
+import "testing"
+
+func TestAnyGetAndConversions(t *testing.T) {
+	a := ParseAny([]byte(`{"name": "Smith, John", "age": 30, "active": true, "tags": ["a", "b"]}`))
+
+	if got := a.Get("name").ToString(); got != "Smith, John" {
+		t.Fatalf("name = %q", got)
+	}
+	if got := a.Get("age").ToInt(); got != 30 {
+		t.Fatalf("age = %v", got)
+	}
+	if got := a.Get("active").ToBool(); got != true {
+		t.Fatalf("active = %v", got)
+	}
+	if got := a.Get("tags", 1).ToString(); got != "b" {
+		t.Fatalf("tags[1] = %q", got)
+	}
+	if got := a.Get("missing").ToString(); got != "" {
+		t.Fatalf("missing = %q, want empty", got)
+	}
+}
+
+func TestAnyKeysAndSize(t *testing.T) {
+	a := ParseAny([]byte(`{"a": 1, "b": 2, "c": 3}`))
+	if got := a.Size(); got != 3 {
+		t.Fatalf("Size() = %d", got)
+	}
+	keys := a.Keys()
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Fatalf("Keys() = %v", keys)
+	}
+}
+
+func TestAnyMustArrayMustMap(t *testing.T) {
+	a := ParseAny([]byte(`[1, "x,y", true]`))
+	items := a.MustArray()
+	if len(items) != 3 {
+		t.Fatalf("MustArray() len = %d", len(items))
+	}
+	if items[1].ToString() != "x,y" {
+		t.Fatalf("items[1] = %q", items[1].ToString())
+	}
+
+	m := ParseAny([]byte(`{"k": "v,w"}`)).MustMap()
+	if m["k"].ToString() != "v,w" {
+		t.Fatalf("m[k] = %q", m["k"].ToString())
+	}
+}
+
+func TestAnyFieldInStruct(t *testing.T) {
+	type Event struct {
+		Name    string
+		Payload Any
+	}
+
+	var e Event
+	if err := Deserialize([]byte(`{"Name": "login", "Payload": {"user": "a,b", "n": 5}}`), &e); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if e.Name != "login" {
+		t.Fatalf("Name = %q", e.Name)
+	}
+	if got := e.Payload.Get("user").ToString(); got != "a,b" {
+		t.Fatalf("Payload.user = %q", got)
+	}
+	if got := e.Payload.Get("n").ToInt(); got != 5 {
+		t.Fatalf("Payload.n = %v", got)
+	}
+}
+
+func TestRawMessageRoundTrip(t *testing.T) {
+	type Envelope struct {
+		Kind string
+		Data RawMessage
+	}
+
+	var e Envelope
+	if err := Deserialize([]byte(`{"Kind": "widget", "Data": {"color": "red, blue"}}`), &e); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if e.Kind != "widget" {
+		t.Fatalf("Kind = %q", e.Kind)
+	}
+	if string(e.Data) != `{"color": "red, blue"}` {
+		t.Fatalf("Data = %q", string(e.Data))
+	}
+
+	out, err := Serialize(e)
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want := "{\n\t\"Kind\": \"widget\",\n\t\"Data\": {\"color\": \"red, blue\"}\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
@@ -1,11 +1,13 @@
 package json
 
 import (
-	"bytes"
 	"fmt"
+	"io"
 	"reflect"
 	"strconv"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 func Deserialize(json []byte, i interface{}) error {
@@ -32,6 +34,54 @@ type deserializer struct {
 	pos  int
 	eof  int
 	err  error
+
+	// r is set when the deserializer is fed from a streaming source (see
+	// Decoder). When non-nil, atEof pulls more bytes from r instead of
+	// immediately reporting end-of-input.
+	r io.Reader
+}
+
+// makeStreamDeserializer builds a deserializer with no backing buffer of its
+// own, filling json incrementally from r as parsing demands more bytes. This
+// is what lets Decoder parse a sequence of values out of a long-running
+// stream instead of requiring the whole document up front.
+func makeStreamDeserializer(r io.Reader) deserializer {
+	return deserializer{
+		json: []byte{},
+		pos:  0,
+		eof:  -1,
+		r:    r,
+	}
+}
+
+// fill reads another chunk from r, appending it to json and advancing eof.
+// It returns false once r is exhausted (or errors), after which it stops
+// being consulted for the rest of this deserializer's lifetime.
+func (d *deserializer) fill() bool {
+	if d.r == nil {
+		return false
+	}
+	buf := make([]byte, 4096)
+	n, err := d.r.Read(buf)
+	if n > 0 {
+		d.json = append(d.json, buf[:n]...)
+		d.eof = len(d.json) - 1
+	}
+	if err != nil {
+		d.r = nil
+	}
+	return n > 0
+}
+
+// discard drops the already-consumed prefix of json so a long-running
+// Decoder doesn't retain every byte it has ever seen.
+func (d *deserializer) discard() {
+	if d.pos == 0 {
+		return
+	}
+	d.json = d.json[d.pos:]
+	d.eof -= d.pos
+	d.pos = 0
 }
 
 type PrimitiveType uint
@@ -56,23 +106,268 @@ func newError(errorMsg string) error {
 	return &deserializationError{errorMsg}
 }
 
-func (d *deserializer) deserialize() error {
-	interfaceValue := reflect.ValueOf(d.i).Elem()
-	if isJsonArray(interfaceValue.Kind()) {
-		d.deserializeArray(interfaceValue)
+// Unmarshaler is implemented by types that want to decode their own JSON
+// representation, in the style of encoding/json.Unmarshaler. It is checked
+// (on the addressable value or, if v is itself a pointer, on v) before
+// deserializeInto falls back to its struct/array/map/primitive dispatch.
+type Unmarshaler interface {
+	UnmarshalJSON([]byte) error
+}
+
+// TextUnmarshaler is implemented by types that decode from the JSON string
+// form of their text representation, in the style of
+// encoding.TextUnmarshaler (time.Time, net.IP, uuid.UUID and similar).
+type TextUnmarshaler interface {
+	UnmarshalText([]byte) error
+}
+
+// tryUnmarshaler checks v (or, if v is addressable, &v) for the Unmarshaler
+// and TextUnmarshaler interfaces, decoding the next value itself if either
+// is implemented. It reports whether it handled the value at all, not
+// whether decoding succeeded - the caller should still check d.hasError()
+// afterward like any other deserializeInto path.
+func (d *deserializer) tryUnmarshaler(v reflect.Value, topLevel bool) bool {
+	if v.Kind() == reflect.Ptr {
+		if d.peekCurrent() == 'n' {
+			// Let the caller's usual null handling decide whether to leave
+			// this field nil or zero it; Unmarshaler only applies to values
+			// actually present on the wire.
+			return false
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+	} else if !v.CanAddr() {
+		return false
+	}
+
+	var target interface{}
+	if v.Kind() == reflect.Ptr {
+		target = v.Interface()
+	} else {
+		target = v.Addr().Interface()
+	}
+
+	if u, ok := target.(Unmarshaler); ok {
+		data := d.skipValueTopLevel(topLevel)
 		if d.hasError() {
-			return d.err
+			return true
+		}
+		if err := u.UnmarshalJSON(append([]byte(nil), data...)); err != nil {
+			d.err = err
 		}
-	} else if interfaceValue.Kind() == reflect.Struct {
-		d.deserializeObject(interfaceValue)
+		return true
+	}
+
+	if tu, ok := target.(TextUnmarshaler); ok {
+		data := d.skipValueTopLevel(topLevel)
 		if d.hasError() {
-			return d.err
+			return true
+		}
+		s, err := parseString(data)
+		if err != nil {
+			d.err = err
+			return true
+		}
+		if err := tu.UnmarshalText([]byte(s)); err != nil {
+			d.err = err
 		}
+		return true
+	}
+
+	return false
+}
+
+func (d *deserializer) deserialize() error {
+	interfaceValue := reflect.ValueOf(d.i).Elem()
+	d.deserializeInto(interfaceValue, true)
+	if d.hasError() {
+		return d.err
 	}
 	return nil
 }
 
+// deserializeInto decodes the next JSON value into v, dispatching on v's
+// kind: objects into structs/maps/interfaces, arrays into arrays/slices/
+// interfaces, pointers are allocated on demand (or left nil on a JSON
+// null), and everything else falls to assignPrimitive.
+//
+// topLevel distinguishes the very first value in the document from values
+// nested inside an object or array. Nested primitives are always followed
+// by a guaranteed terminator ('}', ',' or ']'), which consumeUntilTerminator
+// relies on; a bare top-level primitive has no such guarantee, so it's
+// scanned with consumeBareScalar instead.
+func (d *deserializer) deserializeInto(v reflect.Value, topLevel bool) {
+	if d.hasError() {
+		return
+	}
+
+	if d.tryUnmarshaler(v, topLevel) {
+		return
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if d.peekCurrent() == 'n' {
+			var data []byte
+			if topLevel {
+				data = d.consumeBareScalar()
+			} else {
+				data, _ = d.consumeUntilTerminator()
+			}
+			if err := parseNil(data); err != nil {
+				d.err = err
+				return
+			}
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		d.deserializeInto(v.Elem(), topLevel)
+		return
+	}
+
+	if v.Type() == rawMessageType {
+		v.SetBytes(append([]byte(nil), d.skipValue()...))
+		return
+	}
+	if v.Type() == anyType {
+		v.Set(reflect.ValueOf(newAny(append([]byte(nil), d.skipValue()...))))
+		return
+	}
+
+	switch d.peekCurrent() {
+	case '{':
+		d.deserializeObject(v)
+	case '[':
+		d.deserializeArray(v)
+	default:
+		if !d.isPrimitive() {
+			d.err = newError(fmt.Sprintf("Expected object, array or primitive, got %s", string(d.peekCurrent())))
+			return
+		}
+
+		primitiveType, err := d.parsePrimitiveType()
+		if err != nil {
+			d.err = err
+			return
+		}
+
+		if primitiveType == String && !topLevel {
+			// Nested strings go through consumeStringLiteral rather than
+			// consumeUntilTerminator: the latter has no notion of being
+			// inside quotes, so a string containing a comma, '}' or ']'
+			// (e.g. "Smith, John") would otherwise be cut short.
+			s, err := d.consumeStringLiteral()
+			if err != nil {
+				d.err = err
+				return
+			}
+			d.assignString(v, s)
+			return
+		}
+
+		var data []byte
+		if topLevel {
+			data = d.consumeBareScalar()
+		} else {
+			data, _ = d.consumeUntilTerminator()
+		}
+		d.assignPrimitive(v, primitiveType, data)
+	}
+}
+
+// assignString stores an already-decoded string s into v. It is assignPrimitive's
+// String case factored out for callers, like the nested-string path above,
+// that read the string directly via consumeStringLiteral instead of handing
+// assignPrimitive raw, still-quoted bytes.
+func (d *deserializer) assignString(v reflect.Value, s string) {
+	if v.Kind() == reflect.Interface {
+		v.Set(reflect.ValueOf(s))
+	} else {
+		v.SetString(s)
+	}
+}
+
+// assignPrimitive stores a scanned scalar into v, converting it to whatever
+// concrete type v's kind calls for. An interface{} target gets the same
+// dynamic type choice encoding/json makes: float64, string, bool or nil.
+func (d *deserializer) assignPrimitive(v reflect.Value, t PrimitiveType, data []byte) {
+	switch t {
+	case Number:
+		switch v.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+			uin, err := parseUint(data)
+			if err != nil {
+				d.err = err
+				return
+			}
+			v.SetUint(uin)
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+			in, err := parseInt(data)
+			if err != nil {
+				d.err = err
+				return
+			}
+			v.SetInt(in)
+		case reflect.Float32, reflect.Float64:
+			f, err := parseFloat(data)
+			if err != nil {
+				d.err = err
+				return
+			}
+			v.SetFloat(f)
+		case reflect.Interface:
+			f, err := parseFloat(data)
+			if err != nil {
+				d.err = err
+				return
+			}
+			v.Set(reflect.ValueOf(f))
+		default:
+			d.err = newError(fmt.Sprintf("Cannot assign number into %s", v.Kind().String()))
+		}
+	case Bool:
+		b, err := parseBool(data)
+		if err != nil {
+			d.err = err
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			v.Set(reflect.ValueOf(b))
+		} else {
+			v.SetBool(b)
+		}
+	case String:
+		s, err := parseString(data)
+		if err != nil {
+			d.err = err
+			return
+		}
+		d.assignString(v, s)
+	case Nil:
+		if err := parseNil(data); err != nil {
+			d.err = err
+			return
+		}
+		switch v.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(v.Type()))
+		}
+	}
+}
+
 func (d *deserializer) deserializeArray(v reflect.Value) {
+	if v.Kind() == reflect.Interface {
+		var s []interface{}
+		sv := reflect.ValueOf(&s).Elem()
+		d.deserializeArray(sv)
+		if !d.hasError() {
+			v.Set(sv)
+		}
+		return
+	}
 
 	if !isJsonArray(v.Kind()) {
 		d.err = newError(fmt.Sprintf("Value is not JsonArray. Kind() is %s", v.Kind().String()))
@@ -109,87 +404,18 @@ func (d *deserializer) deserializeArray(v reflect.Value) {
 		}
 
 		if arrayIndex < v.Len() {
-			if d.peekCurrent() == '[' {
-				d.deserializeArray(v.Index(arrayIndex))
-				if d.hasError() {
-					return
-				}
-				if d.peekCurrent() == ',' {
-					d.consumeByte(',')
-				}
-			} else if d.peekCurrent() == '{' {
-				d.deserializeObject(v.Index(arrayIndex))
-				if d.hasError() {
-					return
-				}
-				if d.peekCurrent() == ',' {
-					d.consumeByte(',')
-				}
-			} else if d.isPrimitive() {
-				primitiveType, err := d.parsePrimitiveType()
-				if err != nil {
-					d.err = err
-					return
-				}
-
-				data, terminator := d.consumeUntilTerminator()
-				_ = terminator
-				switch primitiveType {
-				case Number:
-					switch v.Type().Elem().Kind() {
-					case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
-						uin, err := parseUint(data)
-						if err != nil {
-							d.err = err
-							return
-						}
-						v.Index(arrayIndex).SetUint(uin)
-					case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-						in, err := parseInt(data)
-						if err != nil {
-							d.err = err
-							return
-						}
-						v.Index(arrayIndex).SetInt(in)
-					case reflect.Float32, reflect.Float64:
-						f, err := parseFloat(data)
-						if err != nil {
-							d.err = err
-							return
-						}
-						v.Index(arrayIndex).SetFloat(f)
-					default:
-					}
-				case Bool:
-					b, err := parseBool(data)
-					if err != nil {
-						d.err = err
-						return
-					}
-					v.Type()
-					v.Set(reflect.Append(v, reflect.ValueOf(b)))
-				case String:
-					s, err := parseString(data)
-					if err != nil {
-						d.err = err
-						return
-					}
-					v.Set(reflect.Append(v, reflect.ValueOf(s)))
-				case Nil:
-					err := parseNil(data)
-					if err != nil {
-						d.err = err
-						return
-					}
-				}
-				if terminator == ',' {
-					d.consumeByte(terminator)
-				}
-			} else {
-				d.err = newError(fmt.Sprintf("Expected object, array or primitive, got %s in loop num %d", string(d.peekCurrent()), arrayIndex))
+			d.deserializeInto(v.Index(arrayIndex), false)
+			if d.hasError() {
 				return
 			}
+			if d.peekCurrent() == ',' {
+				d.consumeByte(',')
+			}
+		} else {
+			d.err = newError(fmt.Sprintf("Expected object, array or primitive, got %s in loop num %d", string(d.peekCurrent()), arrayIndex))
+			return
 		}
+
 		arrayIndex++
 		d.consumeWhitespace()
 
@@ -204,7 +430,7 @@ func (d *deserializer) deserializeArray(v reflect.Value) {
 					v.Index(arrayIndex).Set(z)
 				}
 			}
-		} else {
+		} else if v.Kind() == reflect.Slice {
 			v.SetLen(arrayIndex)
 		}
 	}
@@ -216,6 +442,20 @@ func (d *deserializer) deserializeArray(v reflect.Value) {
 }
 
 func (d *deserializer) deserializeObject(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Map:
+		d.deserializeMap(v)
+		return
+	case reflect.Interface:
+		m := map[string]interface{}{}
+		mv := reflect.ValueOf(&m).Elem()
+		d.deserializeMap(mv)
+		if !d.hasError() {
+			v.Set(mv)
+		}
+		return
+	}
+
 	d.consumeByte('{')
 	d.consumeWhitespace()
 
@@ -233,101 +473,109 @@ func (d *deserializer) deserializeObject(v reflect.Value) {
 			return
 		}
 
-		keyFieldValue := v.FieldByName(key)
-		if !keyFieldValue.IsValid() {
-			t := reflect.TypeOf(v.Interface())
-			d.err = newError(fmt.Sprintf("Key: %s, could not be found in the interface: %v", key, t.Name()))
+		info := cachedStructInfo(v.Type())
+		fi, ok := info.find(key)
+		if !ok {
+			d.err = newError(fmt.Sprintf("Key: %s, could not be found in the interface: %v", key, v.Type().Name()))
 			return
 		}
+		keyFieldValue := v.Field(fi.index)
 
-		if d.peekCurrent() == '[' {
+		d.deserializeInto(keyFieldValue, false)
+		if d.hasError() {
+			return
+		}
 
-			d.deserializeArray(keyFieldValue)
-			if d.hasError() {
-				return
-			}
-			if d.peekCurrent() == ',' {
-				d.consumeByte(',')
-				d.consumeWhitespace()
-			}
-		} else if d.peekCurrent() == '{' {
-			d.deserializeObject(keyFieldValue)
-			if d.hasError() {
-				return
-			}
-			if d.peekCurrent() == ',' {
-				d.consumeByte(',')
-				d.consumeWhitespace()
-			}
-		} else if d.isPrimitive() {
-			primitiveType, err := d.parsePrimitiveType()
-			if err != nil {
-				d.err = err
-				return
-			}
+		if d.peekCurrent() == ',' {
+			d.consumeByte(',')
+			d.consumeWhitespace()
+		}
+		d.consumeWhitespace()
+	}
+	d.consumeWhitespace()
+	d.consumeByte('}')
 
-			data, terminator := d.consumeUntilTerminator()
-			_ = terminator
-			switch primitiveType {
-			case Number:
-				switch v.Kind() {
-				case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
-					uin, err := parseUint(data)
-					if err != nil {
-						d.err = err
-						return
-					}
-					keyFieldValue.SetUint(uin)
-				case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-					in, err := parseInt(data)
-					if err != nil {
-						d.err = err
-						return
-					}
-					keyFieldValue.SetInt(in)
-				case reflect.Float32, reflect.Float64:
-					f, err := parseFloat(data)
-					if err != nil {
-						d.err = err
-						return
-					}
-					keyFieldValue.SetFloat(f)
-				default:
-				}
-			case Bool:
-				b, err := parseBool(data)
-				if err != nil {
-					d.err = err
-					return
-				}
-				keyFieldValue.SetBool(b)
-			case String:
-				s, err := parseString(data)
-				if err != nil {
-					d.err = err
-					return
-				}
-				keyFieldValue.SetString(s)
-			case Nil:
-				err := parseNil(data)
-				if err != nil {
-					d.err = err
-					return
-				}
-			}
-			if terminator == ',' {
-				d.consumeByte(terminator)
-			}
-		} else {
-			d.err = newError(fmt.Sprintf("Expected object, array or primitive, got %s", string(d.peekCurrent())))
+	return
+}
+
+// deserializeMap decodes a JSON object into v, a map[K]T. Keys are always
+// JSON strings on the wire; convertMapKey handles turning that string into
+// whatever comparable K the map actually uses.
+func (d *deserializer) deserializeMap(v reflect.Value) {
+	if v.Kind() != reflect.Map {
+		d.err = newError(fmt.Sprintf("Value is not a map. Kind() is %s", v.Kind().String()))
+		return
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+
+	d.consumeByte('{')
+	d.consumeWhitespace()
+
+	if d.hasError() {
+		return
+	}
+
+	for {
+		if d.peekCurrent() == '}' {
+			break
+		}
+
+		key := d.consumeKey()
+		if d.hasError() {
+			return
+		}
+
+		elemValue := reflect.New(v.Type().Elem()).Elem()
+		d.deserializeInto(elemValue, false)
+		if d.hasError() {
+			return
+		}
+
+		keyValue, err := convertMapKey(key, v.Type().Key())
+		if err != nil {
+			d.err = err
 			return
 		}
+		v.SetMapIndex(keyValue, elemValue)
+
+		if d.peekCurrent() == ',' {
+			d.consumeByte(',')
+			d.consumeWhitespace()
+		}
 		d.consumeWhitespace()
 	}
 	d.consumeWhitespace()
 	d.consumeByte('}')
+}
 
-	return
+// convertMapKey turns a decoded JSON object key (always a string) into a
+// reflect.Value assignable as the key of a map[K]T, supporting the
+// comparable key kinds that naturally round-trip through a JSON key.
+func convertMapKey(key string, keyType reflect.Type) (reflect.Value, error) {
+	switch keyType.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(key).Convert(keyType), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := parseInt([]byte(key))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		kv := reflect.New(keyType).Elem()
+		kv.SetInt(n)
+		return kv, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := parseUint([]byte(key))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		kv := reflect.New(keyType).Elem()
+		kv.SetUint(n)
+		return kv, nil
+	default:
+		return reflect.Value{}, newError(fmt.Sprintf("Unsupported map key type: %s", keyType.String()))
+	}
 }
 
 func parseFloat(value []byte) (float64, error) {
@@ -368,43 +616,112 @@ func parseString(value []byte) (string, error) {
 	}
 	value = value[:len(value)-1]
 
-	strValue := unescapeString(string(value))
+	strValue, err := unescapeString(string(value))
+	if err != nil {
+		return "", err
+	}
 
 	return strValue, nil
 }
 
-func unescapeString(s string) string {
+// unescapeString decodes the RFC 8259 escape sequences in s (the contents
+// of a JSON string, quotes already stripped), including \uXXXX escapes and
+// the UTF-16 surrogate pairs used to represent astral-plane characters. It
+// errors on truncated or otherwise malformed escapes instead of silently
+// dropping them.
+func unescapeString(s string) (string, error) {
+	b := []byte(s)
 	var sb strings.Builder
 	i := 0
-	for i < len(s) {
-		delimiter := s[i]
-		strDelimiter := string(delimiter)
-		_ = strDelimiter
+	for i < len(b) {
+		c := b[i]
+		if c != '\\' {
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+
 		i++
+		if i >= len(b) {
+			return "", newError("Unexpected end of string escape")
+		}
 
-		if delimiter == '\\' {
-			ch := s[i]
-			strCh := string(ch)
-			_ = strCh
+		switch b[i] {
+		case '\\', '/', '"', '\'':
+			sb.WriteByte(b[i])
+			i++
+		case 'n':
+			sb.WriteByte('\n')
+			i++
+		case 'r':
+			sb.WriteByte('\r')
 			i++
-			if ch == '\\' || ch == '/' || ch == '"' || ch == '\'' {
-				sb.WriteByte(ch)
-			} else if ch == 'n' {
-				sb.WriteByte('\n')
-			} else if ch == 'r' {
-				sb.WriteByte('\r')
-			} else if ch == 'b' {
-				sb.WriteByte('\b')
-			} else if ch == 't' {
-				sb.WriteByte('\t')
-			} else if ch == 'f' {
-				sb.WriteByte('\f')
+		case 'b':
+			sb.WriteByte('\b')
+			i++
+		case 't':
+			sb.WriteByte('\t')
+			i++
+		case 'f':
+			sb.WriteByte('\f')
+			i++
+		case 'u':
+			i++
+			high, err := decodeHex4(b, i)
+			if err != nil {
+				return "", err
 			}
-		} else {
-			sb.WriteByte(delimiter)
+			i += 4
+
+			r := rune(high)
+			if utf16.IsSurrogate(r) {
+				if i+1 >= len(b) || b[i] != '\\' || b[i+1] != 'u' {
+					return "", newError("Expected \\u low surrogate after high surrogate escape")
+				}
+				i += 2
+				low, err := decodeHex4(b, i)
+				if err != nil {
+					return "", err
+				}
+				i += 4
+
+				combined := utf16.DecodeRune(r, rune(low))
+				if combined == utf8.RuneError {
+					return "", newError("Invalid UTF-16 surrogate pair")
+				}
+				sb.WriteRune(combined)
+			} else {
+				sb.WriteRune(r)
+			}
+		default:
+			return "", newError(fmt.Sprintf("Invalid escape character: \\%c", b[i]))
 		}
 	}
-	return sb.String()
+	return sb.String(), nil
+}
+
+// decodeHex4 parses the 4 hex digits at b[pos:pos+4], the payload of a
+// \uXXXX escape.
+func decodeHex4(b []byte, pos int) (uint16, error) {
+	if pos+4 > len(b) {
+		return 0, newError("Invalid \\u escape: not enough hex digits")
+	}
+	var v uint16
+	for i := 0; i < 4; i++ {
+		c := b[pos+i]
+		v <<= 4
+		switch {
+		case c >= '0' && c <= '9':
+			v |= uint16(c - '0')
+		case c >= 'a' && c <= 'f':
+			v |= uint16(c-'a') + 10
+		case c >= 'A' && c <= 'F':
+			v |= uint16(c-'A') + 10
+		default:
+			return 0, newError("Invalid \\u escape: bad hex digit")
+		}
+	}
+	return v, nil
 }
 
 func parseNil(value []byte) error {
@@ -463,17 +780,186 @@ func (d *deserializer) consumeByte(b byte) {
 /*
 	Returns the []byte leading up to the terminator and the terminator
 	NOTE: Does note consume terminator
+
+	This returns a subslice of d.json directly rather than copying bytes
+	through a strings.Builder, which used to be the dominant allocation in
+	the decode path: every scalar in a document paid for a fresh builder and
+	string conversion just to hand parseInt/parseFloat/parseBool/parseString
+	bytes they could have read in place.
 */
 func (d *deserializer) consumeUntilTerminator() ([]byte, byte) {
-	var sb strings.Builder
+	start := d.pos
 	b := d.peekCurrent()
-	for b != '}' && b != ',' && b != ']' {
-		sb.WriteByte(b)
+	for b != '}' && b != ',' && b != ']' && !d.hasError() {
 		d.consume()
 		b = d.peekCurrent()
 	}
+	end := d.pos
+	for end > start && isWhitespace(d.json[end-1]) {
+		end--
+	}
+
+	return d.json[start:end], b
+}
+
+// hasMore reports whether there is at least one more byte to read, pulling
+// from the underlying reader (if any) but without recording an error when
+// the stream is simply, legitimately exhausted. Decoder uses this to tell
+// "no more values" apart from a malformed document.
+func (d *deserializer) hasMore() bool {
+	for d.pos > d.eof && d.fill() {
+	}
+	return d.pos <= d.eof
+}
+
+// consumeStringLiteral reads a quoted JSON string starting at the current
+// position and returns its unescaped contents. Unlike consumeUntilTerminator
+// it understands backslash escapes, so a string containing a comma, brace or
+// bracket doesn't get cut short.
+func (d *deserializer) consumeStringLiteral() (string, error) {
+	d.consumeByte('"')
+	if d.hasError() {
+		return "", d.err
+	}
+	var sb strings.Builder
+	for {
+		// Fast path: a run of plain characters (no quote, backslash or
+		// control byte) can be copied into sb a whole word at once instead
+		// of going through consume()/WriteByte per character.
+		for d.r == nil && d.pos+wordSize <= d.eof+1 {
+			word := loadWord(d.json[d.pos : d.pos+wordSize])
+			if hasStringLiteralBoundary(word) {
+				break
+			}
+			sb.Write(d.json[d.pos : d.pos+wordSize])
+			d.pos += wordSize
+		}
+
+		if d.atEof(0) {
+			return "", d.err
+		}
+		b := d.consume()
+		if b == '"' {
+			break
+		}
+		sb.WriteByte(b)
+		if b == '\\' {
+			if d.atEof(0) {
+				return "", d.err
+			}
+			sb.WriteByte(d.consume())
+		}
+	}
+	return unescapeString(sb.String())
+}
+
+// skipValue advances past the next JSON value without decoding it into any
+// Go value, returning the exact raw bytes it spans. Any and RawMessage use
+// this to defer real parsing until (and unless) a caller actually asks for
+// it.
+func (d *deserializer) skipValue() []byte {
+	return d.skipValueTopLevel(false)
+}
+
+// skipValueTopLevel is skipValue with the same topLevel distinction
+// deserializeInto makes: a bare top-level scalar has no guaranteed
+// terminator ahead, so it's scanned with consumeBareScalar instead of
+// consumeUntilTerminator.
+func (d *deserializer) skipValueTopLevel(topLevel bool) []byte {
+	switch d.peekCurrent() {
+	case '{':
+		start := d.pos
+		d.skipObject()
+		return d.json[start:d.pos]
+	case '[':
+		start := d.pos
+		d.skipArray()
+		return d.json[start:d.pos]
+	case '"':
+		// A quoted string has to be scanned with consumeStringLiteral rather
+		// than consumeUntilTerminator: a comma, '}' or ']' inside the string
+		// isn't a terminator, and consumeUntilTerminator doesn't know that.
+		start := d.pos
+		d.consumeStringLiteral()
+		return d.json[start:d.pos]
+	default:
+		if topLevel {
+			return d.consumeBareScalar()
+		}
+		data, _ := d.consumeUntilTerminator()
+		return data
+	}
+}
+
+func (d *deserializer) skipObject() {
+	d.consumeByte('{')
+	d.consumeWhitespace()
+	for {
+		if d.peekCurrent() == '}' || d.hasError() {
+			break
+		}
+		d.consumeKey()
+		if d.hasError() {
+			return
+		}
+		d.skipValue()
+		if d.hasError() {
+			return
+		}
+		if d.peekCurrent() == ',' {
+			d.consumeByte(',')
+			d.consumeWhitespace()
+		}
+		d.consumeWhitespace()
+	}
+	d.consumeWhitespace()
+	d.consumeByte('}')
+}
 
-	return bytes.TrimRight([]byte(sb.String()), " \n\t\r"), b
+func (d *deserializer) skipArray() {
+	d.consumeByte('[')
+	d.consumeWhitespace()
+	for {
+		if d.peekCurrent() == ']' || d.hasError() {
+			break
+		}
+		d.skipValue()
+		if d.hasError() {
+			return
+		}
+		if d.peekCurrent() == ',' {
+			d.consumeByte(',')
+		}
+		d.consumeWhitespace()
+	}
+	d.consumeWhitespace()
+	d.consumeByte(']')
+}
+
+// consumeBareScalar scans a primitive token that isn't nested inside an
+// object or array, where none of consumeUntilTerminator's terminators are
+// guaranteed to appear. It stops at whitespace, at '}', ']' or ',', or at
+// end of input instead, or,
+// for a quoted string, at the closing quote: consumeStringLiteral is
+// quote/escape-aware, so whitespace inside the string isn't mistaken for the
+// end of the token.
+func (d *deserializer) consumeBareScalar() []byte {
+	if d.peekCurrent() == '"' {
+		start := d.pos
+		d.consumeStringLiteral()
+		return d.json[start:d.pos]
+	}
+
+	var sb strings.Builder
+	for d.hasMore() {
+		b := d.json[d.pos]
+		if isWhitespace(b) || b == '}' || b == ']' || b == ',' {
+			break
+		}
+		sb.WriteByte(b)
+		d.consume()
+	}
+	return []byte(sb.String())
 }
 
 func (d *deserializer) consumeUntil(b byte) string {
@@ -515,6 +1001,17 @@ func isWhitespace(b byte) bool {
 }
 
 func (d *deserializer) consumeWhitespace() {
+	// Fast path: skip a whole word of pure whitespace (typically the
+	// indentation between fields of a pretty-printed document) at once,
+	// instead of re-checking isWhitespace byte by byte. Skipped when
+	// streaming, since d.eof can still grow mid-word as d.fill() runs.
+	for d.r == nil && d.pos+wordSize <= d.eof+1 {
+		if !isAllWhitespaceWord(loadWord(d.json[d.pos : d.pos+wordSize])) {
+			break
+		}
+		d.pos += wordSize
+	}
+
 	for !d.atEof(0) && isWhitespace(d.json[d.pos]) {
 		d.consume()
 	}
@@ -545,6 +1042,8 @@ func (d *deserializer) peekCurrent() byte {
 }
 
 func (d *deserializer) atEof(offset int) bool {
+	for d.pos+offset > d.eof && d.fill() {
+	}
 	if d.pos+offset > d.eof {
 		d.err = newError(eof)
 		return true
@@ -0,0 +1,70 @@
+package json
+
+import "testing"
+
+func TestDeserializeMap(t *testing.T) {
+	var m map[string]int
+	if err := Deserialize([]byte(`{"a": 1, "b": 2}`), &m); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if len(m) != 2 || m["a"] != 1 || m["b"] != 2 {
+		t.Fatalf("got %+v", m)
+	}
+}
+
+func TestDeserializeMapIntKey(t *testing.T) {
+	var m map[int]string
+	if err := Deserialize([]byte(`{"1": "one", "2": "two"}`), &m); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if len(m) != 2 || m[1] != "one" || m[2] != "two" {
+		t.Fatalf("got %+v", m)
+	}
+}
+
+func TestDeserializeInterface(t *testing.T) {
+	var v interface{}
+	if err := Deserialize([]byte(`{"a": 1, "b": [true, "s", null]}`), &v); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		t.Fatalf("got %T, want map[string]interface{}", v)
+	}
+	if m["a"].(float64) != 1 {
+		t.Fatalf("a = %v", m["a"])
+	}
+	arr, ok := m["b"].([]interface{})
+	if !ok || len(arr) != 3 {
+		t.Fatalf("b = %v", m["b"])
+	}
+	if arr[0] != true || arr[1] != "s" || arr[2] != nil {
+		t.Fatalf("b elements = %v", arr)
+	}
+}
+
+func TestDeserializePointer(t *testing.T) {
+	type Inner struct {
+		Value int
+	}
+	type Outer struct {
+		Inner *Inner
+	}
+
+	var o Outer
+	if err := Deserialize([]byte(`{"Inner": {"Value": 7}}`), &o); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if o.Inner == nil || o.Inner.Value != 7 {
+		t.Fatalf("got %+v", o)
+	}
+
+	var o2 Outer
+	if err := Deserialize([]byte(`{"Inner": null}`), &o2); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if o2.Inner != nil {
+		t.Fatalf("got %+v, want nil Inner", o2)
+	}
+}
@@ -0,0 +1,29 @@
+package json
+
+import "testing"
+
+// TestConsumeUntilTerminatorQuoteAware guards against consumeUntilTerminator's
+// terminator scan wandering into a quoted string: a string value containing
+// a comma, '}' or ']' must still decode whole, not get cut short at the
+// first such byte.
+func TestConsumeUntilTerminatorQuoteAware(t *testing.T) {
+	type Person struct {
+		Name string
+	}
+
+	var p Person
+	if err := Deserialize([]byte(`{"Name": "Smith, John"}`), &p); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if p.Name != "Smith, John" {
+		t.Fatalf("Name = %q, want %q", p.Name, "Smith, John")
+	}
+
+	var arr []string
+	if err := Deserialize([]byte(`["a}b", "c]d"]`), &arr); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if len(arr) != 2 || arr[0] != "a}b" || arr[1] != "c]d" {
+		t.Fatalf("arr = %v, want [a}b c]d]", arr)
+	}
+}
@@ -0,0 +1,94 @@
+package json
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestUnicodeEscapeDecode covers a plain \uXXXX escape and a literal
+// already-decoded UTF-8 string side by side.
+func TestUnicodeEscapeDecode(t *testing.T) {
+	var s string
+	if err := Deserialize([]byte("\"caf\\u00e9\""), &s); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if s != "café" {
+		t.Fatalf("got %q, want %q", s, "café")
+	}
+}
+
+// TestSurrogatePairRoundTrip checks an astral-plane character (outside the
+// Basic Multilingual Plane) decodes correctly from its \uXXXX\uXXXX
+// surrogate pair form, and re-encodes back to the literal UTF-8 rune.
+func TestSurrogatePairRoundTrip(t *testing.T) {
+	var s string
+	if err := Deserialize([]byte("\"\\ud83d\\ude00\""), &s); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if s != "😀" {
+		t.Fatalf("got %q, want %q", s, "😀")
+	}
+
+	out, err := Serialize(s)
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	if out != `"😀"` {
+		t.Fatalf("got %q, want %q", out, `"😀"`)
+	}
+}
+
+// TestUnpairedSurrogateEscape checks a high surrogate with no following low
+// surrogate errors out instead of producing an invalid rune.
+func TestUnpairedSurrogateEscape(t *testing.T) {
+	var s string
+	if err := Deserialize([]byte(`"\ud83d"`), &s); err == nil {
+		t.Fatal("expected an error for an unpaired surrogate escape")
+	}
+}
+
+// TestMalformedUnicodeEscape checks a \u escape with non-hex digits errors
+// instead of silently parsing garbage.
+func TestMalformedUnicodeEscape(t *testing.T) {
+	var s string
+	if err := Deserialize([]byte(`"\uZZZZ"`), &s); err == nil {
+		t.Fatal("expected an error for a malformed \\u escape")
+	}
+}
+
+// TestControlCharEscapeOnEncode checks every control character below 0x20
+// is escaped on encode, not just the named ones (\n, \t, ...).
+func TestControlCharEscapeOnEncode(t *testing.T) {
+	out, err := Serialize("a\x01b\nc")
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want := "\"a\\u0001b\\nc\""
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+// TestEncoderSetEscapeHTML checks the Encoder's HTML-safe escaping toggle:
+// on by default, and a no-op pass-through once disabled.
+func TestEncoderSetEscapeHTML(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Encode("<script>&'"); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	want := "\"\\u003cscript\\u003e\\u0026'\"\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode("<script>&'"); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	want = "\"<script>&'\"\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,54 @@
+package json
+
+import "encoding/binary"
+
+// wordSize is how many bytes the helpers below test per iteration. They are
+// a software approximation of what a SIMD scanner does with an intrinsic:
+// load a machine word, compare it against every byte of interest at once,
+// and only fall back to a per-byte loop at the boundary where the answer
+// actually changes. This is the same technique a hand-rolled tokenizer uses
+// to skip whitespace/literal runs without touching each byte individually.
+const wordSize = 8
+
+func loadWord(b []byte) uint64 {
+	return binary.LittleEndian.Uint64(b)
+}
+
+func broadcast(b byte) uint64 {
+	return uint64(b) * 0x0101010101010101
+}
+
+// eqMask implements the exact SWAR "find zero byte" trick (see Hacker's
+// Delight 6-1) against word XOR'd with b broadcast into every lane, giving a
+// mask with the top bit of each lane set iff that lane's original byte was
+// exactly b. No lane carries into its neighbour, so the per-lane result is
+// exact.
+func eqMask(word uint64, b byte) uint64 {
+	x := word ^ broadcast(b)
+	y := (x & 0x7f7f7f7f7f7f7f7f) + 0x7f7f7f7f7f7f7f7f
+	return ^(y | x | 0x7f7f7f7f7f7f7f7f)
+}
+
+// hasLess returns a mask that is nonzero iff some lane's byte is less than
+// n. Borrows between lanes mean a lane above such a byte may be flagged as
+// well, so only the mask as a whole is exact. n must be in [1, 128].
+func hasLess(word uint64, n byte) uint64 {
+	return (word - broadcast(n)) &^ word & 0x8080808080808080
+}
+
+const allLanesSet = 0x8080808080808080
+
+// isAllWhitespaceWord reports whether every byte in word is JSON whitespace
+// (tab, newline, carriage return or space), letting consumeWhitespace skip a
+// whole word at once instead of re-checking isWhitespace byte by byte.
+func isAllWhitespaceWord(word uint64) bool {
+	mask := eqMask(word, '\t') | eqMask(word, '\n') | eqMask(word, '\r') | eqMask(word, ' ')
+	return mask == allLanesSet
+}
+
+// hasStringLiteralBoundary reports whether word contains a byte that
+// consumeStringLiteral's fast path can't blindly copy: a closing quote, the
+// start of an escape sequence, or a raw control character.
+func hasStringLiteralBoundary(word uint64) bool {
+	return eqMask(word, '"')|eqMask(word, '\\')|hasLess(word, 0x20) != 0
+}
@@ -0,0 +1,55 @@
+package json
+
+import "testing"
+
+// benchDocument is representative of the pretty-printed, whitespace-heavy
+// payloads that motivated the zero-copy scanner: a handful of string and
+// number fields nested inside an array, indented with tabs and newlines.
+const benchDocument = `[
+	{
+		"id": 1,
+		"name": "alpha",
+		"tags": ["one", "two", "three"],
+		"active": true,
+		"score": 12.5
+	},
+	{
+		"id": 2,
+		"name": "bravo",
+		"tags": ["four", "five"],
+		"active": false,
+		"score": 98.125
+	}
+]`
+
+type benchRecord struct {
+	Id     int      `json:"id"`
+	Name   string   `json:"name"`
+	Tags   []string `json:"tags"`
+	Active bool     `json:"active"`
+	Score  float64  `json:"score"`
+}
+
+func BenchmarkDeserialize(b *testing.B) {
+	data := []byte(benchDocument)
+	for i := 0; i < b.N; i++ {
+		var records []benchRecord
+		if err := Deserialize(data, &records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConsumeWhitespace(b *testing.B) {
+	data := []byte(benchDocument)
+	for i := 0; i < b.N; i++ {
+		d := makeDeserializer(data, nil)
+		for !d.atEof(0) {
+			d.consumeWhitespace()
+			if d.hasError() {
+				break
+			}
+			d.consume()
+		}
+	}
+}
@@ -1,12 +1,27 @@
 package json
 
 import (
-	_ "fmt"
+	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
+// Marshaler is implemented by types that want to control their own JSON
+// encoding, in the style of encoding/json.Marshaler.
+type Marshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+// TextMarshaler is implemented by types that encode as the JSON string form
+// of their text representation, in the style of encoding.TextMarshaler
+// (time.Time, net.IP, uuid.UUID and similar).
+type TextMarshaler interface {
+	MarshalText() ([]byte, error)
+}
+
 func Serialize(i interface{}) (string, error) {
 	s := makeSerializer(i)
 	err := s.serialize()
@@ -18,59 +33,38 @@ func Serialize(i interface{}) (string, error) {
 
 func makeSerializer(i interface{}) serializer {
 	var b strings.Builder
-	s := serializer{b, i, 0}
-	return s
+	return serializer{json: b, i: i, indent: "\t"}
 }
 
 type serializer struct {
 	json        strings.Builder
 	i           interface{}
 	indentLevel int
-}
 
-func (s *serializer) serialize() error {
-	interfaceValue := reflect.ValueOf(s.i)
-	_ = interfaceValue
-	interfaceType := reflect.TypeOf(s.i)
+	// prefix/indent control how new lines are formatted, mirroring
+	// encoding/json's Indent/SetIndent. compact skips all whitespace,
+	// producing the single-line form Encoder.Encode writes by default.
+	prefix  string
+	indent  string
+	compact bool
 
-	if interfaceType.Kind() == reflect.Struct {
-		err := s.serializeObject(s.i)
-		if err != nil {
-			return err
-		}
-	} else if isJsonArray(interfaceType.Kind()) {
-		err := s.serializeArray(s.i)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	// escapeHTML, when set, escapes '<', '>', '&' and the line separators
+	// U+2028/U+2029 as \uXXXX so the output is safe to embed in HTML/JS.
+	escapeHTML bool
 }
 
-func (s *serializer) serializeArray(i interface{}) error {
-	interfaceValue := reflect.ValueOf(i)
-	// interfaceType := reflect.TypeOf(i)
+func (s *serializer) serialize() error {
+	return s.serializeValue(reflect.ValueOf(s.i))
+}
 
-	if !interfaceValue.IsNil() {
+func (s *serializer) serializeArray(v reflect.Value) error {
+	if !v.IsNil() {
 		s.startArray()
-		for arrayIndex := 0; arrayIndex < interfaceValue.Len(); arrayIndex++ {
-			if interfaceValue.Index(arrayIndex).Kind() == reflect.Struct {
-				err := s.serializeObject(interfaceValue.Index(arrayIndex).Interface())
-				if err != nil {
-					return err
-				}
-			} else if isPrimitive(interfaceValue.Index(arrayIndex).Kind()) {
-				err := s.serializePrimitive(interfaceValue.Index(arrayIndex))
-				if err != nil {
-					return err
-				}
-			} else if isJsonArray(interfaceValue.Index(arrayIndex).Kind()) {
-				err := s.serializeArray(interfaceValue.Index(arrayIndex).Interface())
-				if err != nil {
-					return err
-				}
+		for arrayIndex := 0; arrayIndex < v.Len(); arrayIndex++ {
+			if err := s.serializeValue(v.Index(arrayIndex)); err != nil {
+				return err
 			}
-			if arrayIndex < interfaceValue.Len()-1 {
+			if arrayIndex < v.Len()-1 {
 				s.appendComma()
 			}
 		}
@@ -82,41 +76,185 @@ func (s *serializer) serializeArray(i interface{}) error {
 	return nil
 }
 
-func (s *serializer) serializeObject(i interface{}) error {
-	interfaceValue := reflect.ValueOf(i)
-	interfaceType := reflect.TypeOf(i)
+// serializeValue writes v in whatever form its kind calls for, dispatching
+// to the object/array/map serializers or serializePrimitive as needed. This
+// is the single place new kinds get wired in, rather than duplicating the
+// dispatch in both serializeObject's field loop and serializeArray's
+// element loop.
+func (s *serializer) serializeValue(v reflect.Value) error {
+	if !v.IsValid() {
+		s.json.WriteString("null")
+		return nil
+	}
+
+	if v.Type() == rawMessageType {
+		raw := v.Interface().(RawMessage)
+		if raw == nil {
+			s.json.WriteString("null")
+		} else {
+			s.json.Write(raw)
+		}
+		return nil
+	}
+
+	if handled, err := s.tryMarshaler(v); handled {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			s.json.WriteString("null")
+			return nil
+		}
+		if rb, ok := v.Interface().(rawBacked); ok {
+			s.json.Write(rb.rawBytes())
+			return nil
+		}
+		return s.serializeValue(v.Elem())
+	case reflect.Struct:
+		return s.serializeObject(v)
+	case reflect.Map:
+		return s.serializeMap(v)
+	default:
+		if isJsonArray(v.Kind()) {
+			return s.serializeArray(v)
+		}
+		if isPrimitive(v.Kind()) {
+			return s.serializePrimitive(v)
+		}
+		panic("Unserializable Type: " + v.Kind().String())
+	}
+}
+
+// tryMarshaler checks v (or, if v is addressable, &v) for the Marshaler and
+// TextMarshaler interfaces, writing the value's JSON itself if either is
+// implemented. It reports whether it handled the value at all; the caller
+// should still check the returned error.
+func (s *serializer) tryMarshaler(v reflect.Value) (bool, error) {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return false, nil
+	}
+
+	target := v.Interface()
+	if m, ok := target.(Marshaler); ok {
+		return true, s.writeMarshaled(m)
+	}
+	if tm, ok := target.(TextMarshaler); ok {
+		return true, s.writeTextMarshaled(tm)
+	}
+
+	if v.CanAddr() {
+		addr := v.Addr().Interface()
+		if m, ok := addr.(Marshaler); ok {
+			return true, s.writeMarshaled(m)
+		}
+		if tm, ok := addr.(TextMarshaler); ok {
+			return true, s.writeTextMarshaled(tm)
+		}
+	}
+
+	return false, nil
+}
+
+func (s *serializer) writeMarshaled(m Marshaler) error {
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		s.json.WriteString("null")
+		return nil
+	}
+	s.json.Write(b)
+	return nil
+}
+
+func (s *serializer) writeTextMarshaled(tm TextMarshaler) error {
+	text, err := tm.MarshalText()
+	if err != nil {
+		return err
+	}
+	s.json.WriteByte('"')
+	s.json.WriteString(escapeString(string(text), s.escapeHTML))
+	s.json.WriteByte('"')
+	return nil
+}
+
+// serializeMap writes v (a map[K]T) as a JSON object. Keys are rendered to
+// strings and sorted for deterministic output.
+func (s *serializer) serializeMap(v reflect.Value) error {
+	if v.IsNil() {
+		s.json.WriteString("null")
+		return nil
+	}
+
+	type mapEntry struct {
+		key string
+		val reflect.Value
+	}
+
+	keys := v.MapKeys()
+	entries := make([]mapEntry, 0, len(keys))
+	for _, k := range keys {
+		name, err := mapKeyToString(k)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, mapEntry{name, v.MapIndex(k)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
 
 	s.startObject()
+	for idx, entry := range entries {
+		if idx > 0 {
+			s.appendComma()
+		}
+		s.appendKey(entry.key)
+		if err := s.serializeValue(entry.val); err != nil {
+			return err
+		}
+	}
+	s.endObject()
+	return nil
+}
 
-	for i := 0; i < interfaceValue.NumField(); i++ {
-		fieldValue := interfaceValue.Field(i)
-		fieldType := interfaceType.Field(i)
+func mapKeyToString(k reflect.Value) (string, error) {
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	default:
+		return "", newError("Unsupported map key type: " + k.Kind().String())
+	}
+}
 
-		s.appendKey(fieldType)
+func (s *serializer) serializeObject(v reflect.Value) error {
+	info := cachedStructInfo(v.Type())
 
-		if isPrimitive(fieldValue.Kind()) {
-			err := s.serializePrimitive(fieldValue)
-			if err != nil {
-				return err
-			}
-		} else if fieldValue.Kind() == reflect.Struct {
-			err := s.serializeObject(fieldValue.Interface())
-			if err != nil {
-				return err
-			}
-		} else if isJsonArray(fieldValue.Kind()) {
-			err := s.serializeArray(fieldValue.Interface())
-			if err != nil {
-				return err
-			}
-		} else {
-			panic("Unserializable Type: " + fieldValue.Kind().String())
+	s.startObject()
+
+	written := 0
+	for _, fi := range info.fields {
+		fieldValue := v.Field(fi.index)
+
+		if fi.omitempty && isEmptyValue(fieldValue) {
+			continue
 		}
 
-		// If there is another field after this current field, append a comma
-		if s.shouldAppendComma(interfaceValue, i) {
+		if written > 0 {
 			s.appendComma()
 		}
+		s.appendKey(fi.name)
+
+		if err := s.serializeValue(fieldValue); err != nil {
+			return err
+		}
+
+		written++
 	}
 
 	s.endObject()
@@ -124,45 +262,45 @@ func (s *serializer) serializeObject(i interface{}) error {
 }
 
 func (s *serializer) startObject() {
-	s.json.WriteString("{\n")
+	s.json.WriteByte('{')
 	s.indentLevel++
-	s.appendTabs()
+	s.appendNewline()
 }
 
 func (s *serializer) endObject() {
-	s.json.WriteString("\n")
 	s.indentLevel--
-	s.appendTabs()
+	s.appendNewline()
 	s.json.WriteString("}")
 }
 
 func (s *serializer) startArray() {
-	s.json.WriteString("[\n")
+	s.json.WriteByte('[')
 	s.indentLevel++
-	s.appendTabs()
+	s.appendNewline()
 }
 
 func (s *serializer) endArray() {
-	s.json.WriteString("\n")
 	s.indentLevel--
-	s.appendTabs()
+	s.appendNewline()
 	s.json.WriteString("]")
 }
 
-func (s *serializer) appendTabs() {
+// appendNewline starts a new, indented line unless the serializer is in
+// compact mode, in which case it is a no-op.
+func (s *serializer) appendNewline() {
+	if s.compact {
+		return
+	}
+	s.json.WriteByte('\n')
+	s.json.WriteString(s.prefix)
 	for i := 0; i < s.indentLevel; i++ {
-		s.json.WriteByte('\t')
+		s.json.WriteString(s.indent)
 	}
 }
 
-func (s *serializer) shouldAppendComma(structValue reflect.Value, fieldIndex int) bool {
-	return fieldIndex < structValue.NumField()-1
-}
-
 func (s *serializer) appendComma() {
 	s.json.WriteByte(',')
-	s.json.WriteByte('\n')
-	s.appendTabs()
+	s.appendNewline()
 }
 
 func (s *serializer) serializePrimitive(fieldValue reflect.Value) error {
@@ -182,7 +320,7 @@ func (s *serializer) serializePrimitive(fieldValue reflect.Value) error {
 	case reflect.String:
 		s.json.WriteString("\"")
 		str := fieldValue.String()
-		escapedString := escapeString(str)
+		escapedString := escapeString(str, s.escapeHTML)
 		s.json.WriteString(escapedString)
 		s.json.WriteString("\"")
 		break
@@ -190,38 +328,68 @@ func (s *serializer) serializePrimitive(fieldValue reflect.Value) error {
 	return nil
 }
 
-func escapeString(s string) string {
+// escapeString renders s as the contents of a JSON string, implementing
+// RFC 8259 in full: every control character below 0x20 is escaped (not just
+// the named ones), invalid UTF-8 is replaced with U+FFFD rather than passed
+// through verbatim, and astral-plane runes round-trip as single WriteRune
+// calls (encoding/json leaves them as literal UTF-8, which is valid JSON).
+// When escapeHTML is set, '<', '>', '&' and the U+2028/U+2029 line
+// separators are also \u-escaped so the result is safe to embed in HTML or
+// a <script> tag.
+func escapeString(s string, escapeHTML bool) string {
 	var sb strings.Builder
-	for i := range s {
-		c := s[i]
-		switch c {
-		case '\\':
-			fallthrough
-		case '"':
-			sb.WriteByte('\\')
-			sb.WriteByte(c)
-		case '/':
-			sb.WriteByte('\\')
-			sb.WriteByte(c)
-		case '\b':
-			sb.WriteString("\\b")
-		case '\t':
-			sb.WriteString("\\t")
-		case '\n':
-			sb.WriteString("\\n")
-		case '\f':
-			sb.WriteString("\\f")
-		case '\r':
-			sb.WriteString("\\r")
-		default:
-			sb.WriteByte(c)
+	b := []byte(s)
+	i := 0
+	for i < len(b) {
+		c := b[i]
+
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '\\' || c == '"' || c == '/':
+				sb.WriteByte('\\')
+				sb.WriteByte(c)
+			case c == '\b':
+				sb.WriteString("\\b")
+			case c == '\t':
+				sb.WriteString("\\t")
+			case c == '\n':
+				sb.WriteString("\\n")
+			case c == '\f':
+				sb.WriteString("\\f")
+			case c == '\r':
+				sb.WriteString("\\r")
+			case c < 0x20:
+				fmt.Fprintf(&sb, "\\u%04x", c)
+			case escapeHTML && (c == '<' || c == '>' || c == '&'):
+				fmt.Fprintf(&sb, "\\u%04x", c)
+			default:
+				sb.WriteByte(c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size == 1 {
+			sb.WriteRune(utf8.RuneError)
+			i++
+			continue
 		}
+		if escapeHTML && (r == ' ' || r == ' ') {
+			fmt.Fprintf(&sb, "\\u%04x", r)
+		} else {
+			sb.WriteRune(r)
+		}
+		i += size
 	}
 	return sb.String()
 }
 
-func (s *serializer) appendKey(field reflect.StructField) {
+func (s *serializer) appendKey(name string) {
 	s.json.WriteString("\"")
-	s.json.WriteString(field.Name)
-	s.json.WriteString("\": ")
+	s.json.WriteString(name)
+	s.json.WriteString("\":")
+	if !s.compact {
+		s.json.WriteByte(' ')
+	}
 }
@@ -0,0 +1,85 @@
+package json
+
+import "testing"
+
+func TestSerializeMap(t *testing.T) {
+	out, err := Serialize(map[string]int{"b": 2, "a": 1})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want := "{\n\t\"a\": 1,\n\t\"b\": 2\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestSerializeNilMap(t *testing.T) {
+	var m map[string]int
+	out, err := Serialize(m)
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	if out != "null" {
+		t.Fatalf("got %q, want %q", out, "null")
+	}
+}
+
+func TestSerializePointer(t *testing.T) {
+	type Inner struct {
+		Value int
+	}
+	type Outer struct {
+		Inner *Inner
+	}
+
+	out, err := Serialize(Outer{Inner: &Inner{Value: 7}})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want := "{\n\t\"Inner\": {\n\t\t\"Value\": 7\n\t}\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+
+	out, err = Serialize(Outer{})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want = "{\n\t\"Inner\": null\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestSerializeInterfaceField(t *testing.T) {
+	type Holder struct {
+		Value interface{}
+	}
+
+	out, err := Serialize(Holder{Value: "s"})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want := "{\n\t\"Value\": \"s\"\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+
+	out, err = Serialize(Holder{Value: 5})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want = "{\n\t\"Value\": 5\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+
+	out, err = Serialize(Holder{})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want = "{\n\t\"Value\": null\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
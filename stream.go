@@ -0,0 +1,233 @@
+package json
+
+import (
+	"io"
+)
+
+// Decoder reads successive JSON values from an input stream, in the style of
+// encoding/json's Decoder. It is the tool for JSON Lines/NDJSON payloads and
+// long-running RPC connections where the whole document isn't available (or
+// desirable to buffer) up front.
+type Decoder struct {
+	d     *deserializer
+	stack []tokenFrame
+}
+
+// NewDecoder returns a new Decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	d := makeStreamDeserializer(r)
+	return &Decoder{d: &d}
+}
+
+// Decode reads the next whitespace-delimited JSON value from the stream and
+// stores it in v, which must be a non-nil pointer (the same requirement
+// Deserialize has). It returns io.EOF once the stream is exhausted.
+func (dec *Decoder) Decode(v interface{}) error {
+	d := dec.d
+	d.err = nil
+	d.consumeWhitespace()
+	if d.pos > d.eof {
+		return io.EOF
+	}
+	d.err = nil
+
+	d.i = v
+	if err := d.deserialize(); err != nil {
+		return err
+	}
+	d.discard()
+	return nil
+}
+
+// tokenFrame tracks one level of object/array nesting for Token. expectKey is
+// only meaningful for '{' frames: it is true when the next token read should
+// be an object key rather than a value.
+type tokenFrame struct {
+	delim     byte
+	expectKey bool
+}
+
+// Delim is a JSON array or object delimiter, returned as a Token.
+type Delim byte
+
+func (d Delim) String() string {
+	return string(rune(d))
+}
+
+// Token returns the next JSON token in the stream: a Delim for '{', '}', '['
+// or ']', a string (for both object keys and string values), a float64, a
+// bool, or nil. It lets a caller walk a document without materializing it as
+// a whole, which Decode always does.
+func (dec *Decoder) Token() (interface{}, error) {
+	d := dec.d
+
+	if err := dec.consumeSeparator(); err != nil {
+		return nil, err
+	}
+	d.consumeWhitespace()
+	if d.pos > d.eof {
+		if len(dec.stack) == 0 {
+			return nil, io.EOF
+		}
+		d.err = newError(eof)
+		return nil, d.err
+	}
+
+	switch d.peekCurrent() {
+	case '{':
+		d.consume()
+		dec.stack = append(dec.stack, tokenFrame{delim: '{', expectKey: true})
+		return Delim('{'), nil
+	case '}':
+		d.consume()
+		dec.popFrame()
+		return Delim('}'), nil
+	case '[':
+		d.consume()
+		dec.stack = append(dec.stack, tokenFrame{delim: '[', expectKey: false})
+		return Delim('['), nil
+	case ']':
+		d.consume()
+		dec.popFrame()
+		return Delim(']'), nil
+	case '"':
+		s, err := d.consumeStringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if dec.inObjectKeyPosition() {
+			d.consumeWhitespace()
+			d.consumeByte(':')
+			d.consumeWhitespace()
+			if d.hasError() {
+				return nil, d.err
+			}
+			dec.setExpectKey(false)
+		} else {
+			dec.afterValue()
+		}
+		return s, nil
+	default:
+		primitiveType, err := d.parsePrimitiveType()
+		if err != nil {
+			return nil, err
+		}
+
+		var data []byte
+		if len(dec.stack) == 0 {
+			data = d.consumeBareScalar()
+		} else {
+			data, _ = d.consumeUntilTerminator()
+		}
+		dec.afterValue()
+
+		switch primitiveType {
+		case Number:
+			return parseFloat(data)
+		case Bool:
+			return parseBool(data)
+		case Nil:
+			return nil, parseNil(data)
+		default:
+			return nil, newError("Unexpected primitive type while tokenizing")
+		}
+	}
+}
+
+func (dec *Decoder) popFrame() {
+	if len(dec.stack) > 0 {
+		dec.stack = dec.stack[:len(dec.stack)-1]
+	}
+	dec.afterValue()
+}
+
+func (dec *Decoder) afterValue() {
+	if len(dec.stack) == 0 {
+		return
+	}
+	dec.setExpectKey(true)
+}
+
+func (dec *Decoder) setExpectKey(expectKey bool) {
+	top := &dec.stack[len(dec.stack)-1]
+	if top.delim == '{' {
+		top.expectKey = expectKey
+	}
+}
+
+func (dec *Decoder) inObjectKeyPosition() bool {
+	if len(dec.stack) == 0 {
+		return false
+	}
+	top := dec.stack[len(dec.stack)-1]
+	return top.delim == '{' && top.expectKey
+}
+
+// consumeSeparator eats the comma between two sibling elements, if one is
+// next. It is a no-op at the very start of an object/array or at top level.
+func (dec *Decoder) consumeSeparator() error {
+	d := dec.d
+	if len(dec.stack) == 0 {
+		return nil
+	}
+	d.consumeWhitespace()
+	if !d.hasMore() {
+		return nil
+	}
+	if d.json[d.pos] == ',' {
+		d.consume()
+		d.consumeWhitespace()
+	}
+	return d.err
+}
+
+// Encoder writes JSON values to an output stream, one per Encode call.
+type Encoder struct {
+	w          io.Writer
+	prefix     string
+	indent     string
+	compact    bool
+	escapeHTML bool
+}
+
+// NewEncoder returns a new Encoder that writes to w. Writes are compact and
+// single-line until SetIndent is called, and HTML-unsafe characters are
+// escaped until SetEscapeHTML(false) is called, matching encoding/json.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, compact: true, escapeHTML: true}
+}
+
+// SetEscapeHTML specifies whether '<', '>', '&' and the U+2028/U+2029 line
+// separators should be escaped as \uXXXX sequences, the same toggle
+// encoding/json.Encoder exposes.
+func (enc *Encoder) SetEscapeHTML(on bool) {
+	enc.escapeHTML = on
+}
+
+// SetIndent instructs the Encoder to format each subsequent Encode call with
+// the given line prefix and per-level indent, the same as
+// encoding/json.Encoder.SetIndent. Passing two empty strings restores the
+// compact, single-line default.
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.prefix = prefix
+	enc.indent = indent
+	enc.compact = prefix == "" && indent == ""
+}
+
+// Encode writes the JSON encoding of v to the stream, followed by a newline.
+func (enc *Encoder) Encode(v interface{}) error {
+	s := makeSerializer(v)
+	s.prefix = enc.prefix
+	s.indent = enc.indent
+	s.compact = enc.compact
+	s.escapeHTML = enc.escapeHTML
+
+	if err := s.serialize(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(enc.w, s.json.String()); err != nil {
+		return err
+	}
+	_, err := io.WriteString(enc.w, "\n")
+	return err
+}
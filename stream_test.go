@@ -0,0 +1,113 @@
+package json
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// TestDecoderDecodeNDJSON exercises the NDJSON use case the streaming
+// Decoder exists for: a sequence of whitespace-delimited values read off of
+// an io.Reader one Decode call at a time.
+func TestDecoderDecodeNDJSON(t *testing.T) {
+	type Record struct {
+		Id   int
+		Name string
+	}
+
+	r := bytes.NewBufferString("{\"Id\": 1, \"Name\": \"alpha\"}\n{\"Id\": 2, \"Name\": \"bravo\"}\n")
+	dec := NewDecoder(r)
+
+	var got []Record
+	for {
+		var rec Record
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		got = append(got, rec)
+	}
+
+	want := []Record{{1, "alpha"}, {2, "bravo"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+// TestDecoderDecodeBareString covers a bare top-level string value, whose
+// whitespace-terminated scan must not be confused by whitespace inside the
+// string's own quotes.
+func TestDecoderDecodeBareString(t *testing.T) {
+	dec := NewDecoder(bytes.NewBufferString("\"hello world\"\n\"second value\"\n"))
+
+	var first, second string
+	if err := dec.Decode(&first); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if err := dec.Decode(&second); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if first != "hello world" || second != "second value" {
+		t.Fatalf("got %q, %q", first, second)
+	}
+
+	if err := dec.Decode(&first); err != io.EOF {
+		t.Fatalf("Decode at end of stream: got %v, want io.EOF", err)
+	}
+}
+
+// TestDecoderToken walks a document token by token, checking delimiters,
+// object keys and values are reported in document order.
+func TestDecoderToken(t *testing.T) {
+	dec := NewDecoder(bytes.NewBufferString(`{"a": 1, "b": [true, null, "x,y"]}`))
+
+	var got []interface{}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+		got = append(got, tok)
+	}
+
+	want := []interface{}{
+		Delim('{'), "a", float64(1), "b", Delim('['), true, nil, "x,y", Delim(']'), Delim('}'),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v tokens, want %v: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("token %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestEncoderEncode checks the compact, one-value-per-line behavior Encoder
+// adds on top of Serialize.
+func TestEncoderEncode(t *testing.T) {
+	type Point struct {
+		X int
+		Y int
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Encode(Point{1, 2}); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if err := enc.Encode(Point{3, 4}); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	want := "{\"X\":1,\"Y\":2}\n{\"X\":3,\"Y\":4}\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
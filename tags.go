@@ -0,0 +1,110 @@
+package json
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// fieldInfo describes how one exported struct field should be named in JSON
+// and whether it should be dropped from the output when empty, as resolved
+// from its `json` struct tag.
+type fieldInfo struct {
+	index     int
+	name      string
+	omitempty bool
+}
+
+// structInfo is the resolved, tag-aware field list for one struct type.
+// byName maps the resolved JSON name to an index into fields, so encoding
+// and decoding both skip the reflection/tag-parsing walk on every value.
+type structInfo struct {
+	fields []fieldInfo
+	byName map[string]int
+}
+
+// find looks a JSON object key up against the struct's resolved field names,
+// first with an exact match and then, to match encoding/json's leniency,
+// case-insensitively.
+func (si *structInfo) find(key string) (fieldInfo, bool) {
+	if idx, ok := si.byName[key]; ok {
+		return si.fields[idx], true
+	}
+	for _, fi := range si.fields {
+		if strings.EqualFold(fi.name, key) {
+			return fi, true
+		}
+	}
+	return fieldInfo{}, false
+}
+
+var structInfoCache sync.Map // reflect.Type -> *structInfo
+
+// cachedStructInfo returns the structInfo for t, building and caching it on
+// first use. This is the standard per-type field index json-iterator and
+// easyjson-style libraries use to keep reflection off the hot path.
+func cachedStructInfo(t reflect.Type) *structInfo {
+	if cached, ok := structInfoCache.Load(t); ok {
+		return cached.(*structInfo)
+	}
+	info := buildStructInfo(t)
+	actual, _ := structInfoCache.LoadOrStore(t, info)
+	return actual.(*structInfo)
+}
+
+func buildStructInfo(t reflect.Type) *structInfo {
+	info := &structInfo{byName: map[string]int{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// Unexported field; reflection can't set it anyway.
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := field.Name
+		omitempty := false
+		if tag != "" {
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					omitempty = true
+				}
+			}
+		}
+
+		fi := fieldInfo{index: i, name: name, omitempty: omitempty}
+		info.byName[name] = len(info.fields)
+		info.fields = append(info.fields, fi)
+	}
+
+	return info
+}
+
+// isEmptyValue reports whether v is the zero value for its type, the same
+// rule encoding/json uses to decide what omitempty drops.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package json
+
+import "testing"
+
+func TestStructTagRename(t *testing.T) {
+	type User struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	var u User
+	if err := Deserialize([]byte(`{"name": "Ada", "age": 30}`), &u); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if u.Name != "Ada" || u.Age != 30 {
+		t.Fatalf("got %+v", u)
+	}
+
+	out, err := Serialize(u)
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want := "{\n\t\"name\": \"Ada\",\n\t\"age\": 30\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestStructTagOmitempty(t *testing.T) {
+	type Config struct {
+		Name    string `json:"name"`
+		Comment string `json:"comment,omitempty"`
+		Count   int    `json:"count,omitempty"`
+	}
+
+	out, err := Serialize(Config{Name: "a"})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want := "{\n\t\"name\": \"a\"\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+
+	out, err = Serialize(Config{Name: "a", Comment: "hi", Count: 2})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want = "{\n\t\"name\": \"a\",\n\t\"comment\": \"hi\",\n\t\"count\": 2\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestStructTagSkip(t *testing.T) {
+	type Secret struct {
+		Name     string `json:"name"`
+		Password string `json:"-"`
+	}
+
+	out, err := Serialize(Secret{Name: "a", Password: "shh"})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	want := "{\n\t\"name\": \"a\"\n}"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+
+	var s Secret
+	if err := Deserialize([]byte(`{"name": "a"}`), &s); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if s.Name != "a" || s.Password != "" {
+		t.Fatalf("got %+v", s)
+	}
+}
+
+func TestStructTagCaseInsensitiveFallback(t *testing.T) {
+	type User struct {
+		Name string `json:"name"`
+	}
+
+	var u User
+	if err := Deserialize([]byte(`{"NAME": "Ada"}`), &u); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if u.Name != "Ada" {
+		t.Fatalf("got %+v", u)
+	}
+}